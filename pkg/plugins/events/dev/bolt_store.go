@@ -0,0 +1,167 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var subscribersBucket = []byte("subscribers")
+
+// BoltSubscriptionStore is a SubscriptionStore backed by an embedded BoltDB
+// database, for deployments that want persistence without operating a
+// separate database process. Each topic is a key in the subscribers
+// bucket, with its subscribers JSON-encoded as the value.
+type BoltSubscriptionStore struct {
+	db *bolt.DB
+}
+
+// NewBoltSubscriptionStore opens (or creates) the BoltDB database at path.
+func NewBoltSubscriptionStore(path string) (*BoltSubscriptionStore, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(subscribersBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltSubscriptionStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file handle.
+func (s *BoltSubscriptionStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltSubscriptionStore) ListTopics() ([]string, error) {
+	var topics []string
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(subscribersBucket).ForEach(func(topic, _ []byte) error {
+			topics = append(topics, string(topic))
+			return nil
+		})
+	})
+
+	return topics, err
+}
+
+func (s *BoltSubscriptionStore) GetSubscribers(topic string) ([]Subscriber, error) {
+	var subscribers []Subscriber
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(subscribersBucket).Get([]byte(topic))
+		if value == nil {
+			return ErrTopicNotFound
+		}
+
+		return json.Unmarshal(value, &subscribers)
+	})
+
+	return subscribers, err
+}
+
+func (s *BoltSubscriptionStore) AddSubscriber(topic string, subscriber Subscriber) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+
+		value := bucket.Get([]byte(topic))
+		if value == nil {
+			return ErrTopicNotFound
+		}
+
+		var subscribers []Subscriber
+		if err := json.Unmarshal(value, &subscribers); err != nil {
+			return err
+		}
+
+		subscribers = append(subscribers, subscriber)
+
+		encoded, err := json.Marshal(subscribers)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(topic), encoded)
+	})
+}
+
+func (s *BoltSubscriptionStore) RemoveSubscriber(topic string, url string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+
+		value := bucket.Get([]byte(topic))
+		if value == nil {
+			return ErrTopicNotFound
+		}
+
+		var subscribers []Subscriber
+		if err := json.Unmarshal(value, &subscribers); err != nil {
+			return err
+		}
+
+		for i, subscriber := range subscribers {
+			if subscriber.URL == url {
+				subscribers = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+
+		encoded, err := json.Marshal(subscribers)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(topic), encoded)
+	})
+}
+
+func (s *BoltSubscriptionStore) CreateTopic(topic string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+
+		if bucket.Get([]byte(topic)) != nil {
+			return ErrTopicExists
+		}
+
+		encoded, err := json.Marshal([]Subscriber{})
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(topic), encoded)
+	})
+}
+
+func (s *BoltSubscriptionStore) DeleteTopic(topic string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+
+		if bucket.Get([]byte(topic)) == nil {
+			return ErrTopicNotFound
+		}
+
+		return bucket.Delete([]byte(topic))
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// retryPolicyConfig is the on-disk representation of a RetryPolicy, using
+// duration strings (e.g. "100ms") so the config file stays human editable.
+type retryPolicyConfig struct {
+	MaxAttempts  int     `json:"maxAttempts"`
+	InitialDelay string  `json:"initialDelay"`
+	Multiplier   float64 `json:"multiplier"`
+	MaxDelay     string  `json:"maxDelay"`
+	Jitter       float64 `json:"jitter"`
+}
+
+func (c retryPolicyConfig) toRetryPolicy() (RetryPolicy, error) {
+	policy := RetryPolicy{
+		MaxAttempts: c.MaxAttempts,
+		Multiplier:  c.Multiplier,
+		Jitter:      c.Jitter,
+	}
+
+	if c.InitialDelay != "" {
+		delay, err := time.ParseDuration(c.InitialDelay)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("invalid initialDelay: %w", err)
+		}
+		policy.InitialDelay = delay
+	}
+
+	if c.MaxDelay != "" {
+		delay, err := time.ParseDuration(c.MaxDelay)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("invalid maxDelay: %w", err)
+		}
+		policy.MaxDelay = delay
+	}
+
+	return policy, nil
+}
+
+// LoadRetryPolicies reads a JSON file mapping topic name to RetryPolicy
+// config, for use with WithRetryPolicy at startup.
+//
+// Example file contents:
+//
+//	{
+//	  "orders.created": {"maxAttempts": 5, "initialDelay": "100ms", "multiplier": 2, "maxDelay": "5s", "jitter": 0.1}
+//	}
+func LoadRetryPolicies(path string) (map[string]RetryPolicy, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConfig := make(map[string]retryPolicyConfig)
+	if err := json.Unmarshal(fileBytes, &rawConfig); err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]RetryPolicy, len(rawConfig))
+
+	for topic, cfg := range rawConfig {
+		policy, err := cfg.toRetryPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topic, err)
+		}
+		policies[topic] = policy
+	}
+
+	return policies, nil
+}
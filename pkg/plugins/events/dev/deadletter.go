@@ -0,0 +1,109 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+)
+
+// DeadLetterEntry records a single delivery that exhausted its RetryPolicy.
+type DeadLetterEntry struct {
+	Topic     string              `json:"topic"`
+	URL       string              `json:"url"`
+	Event     *events.NitricEvent `json:"event"`
+	Attempts  int                 `json:"attempts"`
+	LastError string              `json:"lastError"`
+}
+
+// DeadLetterSink receives events that could not be delivered after all
+// configured retry attempts were exhausted.
+type DeadLetterSink interface {
+	Capture(entry DeadLetterEntry) error
+}
+
+// MemoryDeadLetterSink keeps dead-lettered entries in process memory, mainly
+// useful for local development and tests.
+type MemoryDeadLetterSink struct {
+	mu      sync.Mutex
+	entries []DeadLetterEntry
+}
+
+// NewMemoryDeadLetterSink creates a new, empty MemoryDeadLetterSink.
+func NewMemoryDeadLetterSink() *MemoryDeadLetterSink {
+	return &MemoryDeadLetterSink{}
+}
+
+func (s *MemoryDeadLetterSink) Capture(entry DeadLetterEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+
+	return nil
+}
+
+// Entries returns a copy of the entries captured so far.
+func (s *MemoryDeadLetterSink) Entries() []DeadLetterEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]DeadLetterEntry, len(s.entries))
+	copy(entries, s.entries)
+
+	return entries
+}
+
+// FileDeadLetterSink appends captured entries as newline-delimited JSON to a
+// file on disk, fsync'ing after each write so entries survive a crash.
+type FileDeadLetterSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDeadLetterSink opens (creating if necessary) path for append and
+// returns a FileDeadLetterSink backed by it.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileDeadLetterSink{file: file}, nil
+}
+
+func (s *FileDeadLetterSink) Capture(entry DeadLetterEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(append(entryBytes, '\n')); err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// Close closes the underlying file.
+func (s *FileDeadLetterSink) Close() error {
+	return s.file.Close()
+}
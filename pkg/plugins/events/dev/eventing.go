@@ -0,0 +1,583 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events_service provides a local, HTTP based implementation of the
+// events.EventService for use when running functions against the Nitric dev
+// membrane.
+package events_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+)
+
+// defaultFanOutWorkers bounds how many subscribers are delivered to
+// concurrently for a single Publish call.
+const defaultFanOutWorkers = 8
+
+// LocalHttpeventsClient is the subset of *http.Client used to deliver events
+// to subscriber endpoints. It is satisfied by *http.Client and allows tests
+// to substitute a mock transport.
+type LocalHttpeventsClient interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// cloudEventEnvelope is the JSON shape used for the structured content mode
+// defined by the CloudEvents 1.0 HTTP protocol binding.
+type cloudEventEnvelope struct {
+	ID          string      `json:"id"`
+	Source      string      `json:"source"`
+	SpecVersion string      `json:"specversion"`
+	Type        string      `json:"type"`
+	Time        string      `json:"time"`
+	Data        interface{} `json:"data"`
+}
+
+const cloudEventsSpecVersion = "1.0"
+
+// HttpEventService publishes events to subscriber endpoints over HTTP, using
+// the legacy x-nitric-* header scheme by default.
+type HttpEventService struct {
+	events.UnimplementedeventsPlugin
+	client             LocalHttpeventsClient
+	format             events.EventFormat
+	clock              Clock
+	defaultRetryPolicy RetryPolicy
+	retryPolicies      map[string]RetryPolicy
+	deadLetterSink     DeadLetterSink
+	fanOutWorkers      int
+
+	store  SubscriptionStore
+	subsMu sync.RWMutex
+	subs   map[string][]compiledSubscriber
+
+	channelSubsMu     sync.Mutex
+	channelSubs       map[string][]*channelSubscription
+	redeliveryTimeout time.Duration
+}
+
+// EventServiceOption configures an HttpEventService at construction time.
+type EventServiceOption func(*HttpEventService)
+
+// WithEventFormat sets the wire format used when delivering events to
+// subscribers. Defaults to events.EventFormatHeaders.
+func WithEventFormat(format events.EventFormat) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.format = format
+	}
+}
+
+// WithDefaultRetryPolicy sets the RetryPolicy applied to topics that don't
+// have a more specific policy configured via WithRetryPolicy.
+func WithDefaultRetryPolicy(policy RetryPolicy) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.defaultRetryPolicy = policy
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy used when delivering events published
+// to topic, overriding the default policy for that topic only.
+func WithRetryPolicy(topic string, policy RetryPolicy) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.retryPolicies[topic] = policy
+	}
+}
+
+// WithRetryPoliciesFromFile loads per-topic retry policies from a JSON
+// config file at startup. See LoadRetryPolicies for the file format.
+func WithRetryPoliciesFromFile(path string) EventServiceOption {
+	return func(s *HttpEventService) {
+		policies, err := LoadRetryPolicies(path)
+		if err != nil {
+			return
+		}
+
+		for topic, policy := range policies {
+			s.retryPolicies[topic] = policy
+		}
+	}
+}
+
+// WithDeadLetterSink configures where deliveries are persisted once a
+// subscriber's RetryPolicy has been exhausted.
+func WithDeadLetterSink(sink DeadLetterSink) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.deadLetterSink = sink
+	}
+}
+
+// WithClock overrides the Clock used to schedule retry backoff, for use in
+// tests that need deterministic timing.
+func WithClock(clock Clock) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.clock = clock
+	}
+}
+
+// WithRedeliveryTimeout overrides how long a channel subscriber (see
+// Subscribe) has to Ack a Delivery before it is automatically redelivered.
+// Defaults to defaultRedeliveryTimeout.
+func WithRedeliveryTimeout(timeout time.Duration) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.redeliveryTimeout = timeout
+	}
+}
+
+// WithSubscriptionStore overrides the SubscriptionStore used to persist
+// topic and subscriber state. Defaults to a MemorySubscriptionStore. Any
+// topics/subscribers the store already contains (e.g. loaded from a
+// previous run of a FileSubscriptionStore or BoltSubscriptionStore) are
+// merged with the bootstrap subscription map passed to the constructor.
+func WithSubscriptionStore(store SubscriptionStore) EventServiceOption {
+	return func(s *HttpEventService) {
+		s.store = store
+	}
+}
+
+// NewWithClientAndSubscriptions creates a new HttpEventService with the
+// given http client and topic/subscriber map. Filter expressions are
+// parsed eagerly, so a malformed Filter is reported here rather than on a
+// later Publish call.
+func NewWithClientAndSubscriptions(client LocalHttpeventsClient, subs SubscriptionMap, opts ...EventServiceOption) (events.EventService, error) {
+	compiled := make(map[string][]compiledSubscriber, len(subs))
+
+	for topic, subscribers := range subs {
+		compiledSubs := make([]compiledSubscriber, 0, len(subscribers))
+
+		for _, subscriber := range subscribers {
+			filter, err := parseFilter(subscriber.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("topic %s: %w", topic, err)
+			}
+
+			compiledSubs = append(compiledSubs, compiledSubscriber{Subscriber: subscriber, filter: filter})
+		}
+
+		compiled[topic] = compiledSubs
+	}
+
+	service := &HttpEventService{
+		client:             client,
+		subs:               compiled,
+		store:              NewMemorySubscriptionStore(),
+		format:             events.EventFormatHeaders,
+		clock:              realClock{},
+		defaultRetryPolicy: DefaultRetryPolicy,
+		retryPolicies:      map[string]RetryPolicy{},
+		fanOutWorkers:      defaultFanOutWorkers,
+		channelSubs:        map[string][]*channelSubscription{},
+		redeliveryTimeout:  defaultRedeliveryTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	if err := service.migrate(subs); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// migrate reconciles the bootstrap subscription map passed to the
+// constructor with whatever the configured SubscriptionStore already
+// contains (e.g. state persisted by a previous run), so neither loses data:
+// bootstrap topics/subscribers not already in the store are added to it
+// (skipping ones already present, so restarting doesn't pile up
+// duplicates), then the in-memory delivery cache is rebuilt from the
+// store's merged view.
+func (s *HttpEventService) migrate(subs SubscriptionMap) error {
+	for topic, subscribers := range subs {
+		if err := s.store.CreateTopic(topic); err != nil && !errors.Is(err, ErrTopicExists) {
+			return fmt.Errorf("migrating topic %s: %w", topic, err)
+		}
+
+		existing, err := s.store.GetSubscribers(topic)
+		if err != nil {
+			return fmt.Errorf("loading subscribers for topic %s: %w", topic, err)
+		}
+
+		for _, subscriber := range subscribers {
+			if containsSubscriberURL(existing, subscriber.URL) {
+				continue
+			}
+
+			if err := s.store.AddSubscriber(topic, subscriber); err != nil {
+				return fmt.Errorf("migrating subscriber for topic %s: %w", topic, err)
+			}
+		}
+	}
+
+	topics, err := s.store.ListTopics()
+	if err != nil {
+		return fmt.Errorf("loading topics: %w", err)
+	}
+
+	for _, topic := range topics {
+		subscribers, err := s.store.GetSubscribers(topic)
+		if err != nil {
+			return fmt.Errorf("loading subscribers for topic %s: %w", topic, err)
+		}
+
+		if err := s.setCompiledSubscribers(topic, subscribers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsSubscriberURL(subscribers []Subscriber, url string) bool {
+	for _, subscriber := range subscribers {
+		if subscriber.URL == url {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setCompiledSubscribers replaces the compiled delivery cache for topic,
+// parsing each subscriber's filter eagerly.
+func (s *HttpEventService) setCompiledSubscribers(topic string, subscribers []Subscriber) error {
+	compiledSubs := make([]compiledSubscriber, 0, len(subscribers))
+
+	for _, subscriber := range subscribers {
+		filter, err := parseFilter(subscriber.Filter)
+		if err != nil {
+			return fmt.Errorf("topic %s: %w", topic, err)
+		}
+
+		compiledSubs = append(compiledSubs, compiledSubscriber{Subscriber: subscriber, filter: filter})
+	}
+
+	s.subsMu.Lock()
+	s.subs[topic] = compiledSubs
+	s.subsMu.Unlock()
+
+	return nil
+}
+
+// CreateTopic registers a new, subscriber-less topic with both the
+// SubscriptionStore and the delivery cache.
+func (s *HttpEventService) CreateTopic(topic string) error {
+	if err := s.store.CreateTopic(topic); err != nil {
+		return err
+	}
+
+	s.subsMu.Lock()
+	s.subs[topic] = []compiledSubscriber{}
+	s.subsMu.Unlock()
+
+	return nil
+}
+
+// DeleteTopic removes topic, and any subscribers registered against it,
+// from both the SubscriptionStore and the delivery cache.
+func (s *HttpEventService) DeleteTopic(topic string) error {
+	if err := s.store.DeleteTopic(topic); err != nil {
+		return err
+	}
+
+	s.subsMu.Lock()
+	delete(s.subs, topic)
+	s.subsMu.Unlock()
+
+	return nil
+}
+
+// AddSubscriber registers subscriber against topic at runtime. The filter
+// expression is parsed before anything is persisted, so a malformed filter
+// is rejected without mutating the store.
+func (s *HttpEventService) AddSubscriber(topic string, subscriber Subscriber) error {
+	filter, err := parseFilter(subscriber.Filter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.AddSubscriber(topic, subscriber); err != nil {
+		return err
+	}
+
+	s.subsMu.Lock()
+	s.subs[topic] = append(s.subs[topic], compiledSubscriber{Subscriber: subscriber, filter: filter})
+	s.subsMu.Unlock()
+
+	return nil
+}
+
+// RemoveSubscriber unregisters the subscriber at url from topic.
+func (s *HttpEventService) RemoveSubscriber(topic string, url string) error {
+	if err := s.store.RemoveSubscriber(topic, url); err != nil {
+		return err
+	}
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	subscribers := s.subs[topic]
+	for i, subscriber := range subscribers {
+		if subscriber.URL == url {
+			s.subs[topic] = append(subscribers[:i], subscribers[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// NewWithClientAndSubs is a back-compat constructor for callers still using
+// the original map[string][]string subscription shape, where every URL is
+// treated as an unfiltered subscriber. New callers should prefer
+// NewWithClientAndSubscriptions.
+func NewWithClientAndSubs(client LocalHttpeventsClient, subs map[string][]string, opts ...EventServiceOption) (events.EventService, error) {
+	richSubs := make(SubscriptionMap, len(subs))
+
+	for topic, urls := range subs {
+		subscribers := make([]Subscriber, len(urls))
+		for i, url := range urls {
+			subscribers[i] = Subscriber{URL: url}
+		}
+
+		richSubs[topic] = subscribers
+	}
+
+	return NewWithClientAndSubscriptions(client, richSubs, opts...)
+}
+
+// retryPolicyFor returns the RetryPolicy configured for topic, falling back
+// to the service's default policy. A policy with fewer than one MaxAttempts
+// (e.g. loaded from a config file that didn't set it) is treated as one
+// attempt, so deliver's retry loop always runs at least once.
+func (s *HttpEventService) retryPolicyFor(topic string) RetryPolicy {
+	policy, ok := s.retryPolicies[topic]
+	if !ok {
+		policy = s.defaultRetryPolicy
+	}
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	return policy
+}
+
+func (s *HttpEventService) ListTopics() ([]string, error) {
+	s.subsMu.RLock()
+	defer s.subsMu.RUnlock()
+
+	topics := make([]string, 0, len(s.subs))
+
+	for topic := range s.subs {
+		topics = append(topics, topic)
+	}
+
+	return topics, nil
+}
+
+func (s *HttpEventService) Publish(topic string, event *events.NitricEvent) error {
+	s.subsMu.RLock()
+	subscribers, ok := s.subs[topic]
+	subscribers = append([]compiledSubscriber{}, subscribers...)
+	s.subsMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("topic %s does not exist", topic)
+	}
+
+	s.notifyChannelSubscribers(topic, event)
+
+	policy := s.retryPolicyFor(topic)
+
+	workers := s.fanOutWorkers
+	if workers <= 0 {
+		workers = defaultFanOutWorkers
+	}
+	if workers > len(subscribers) {
+		workers = len(subscribers)
+	}
+
+	jobs := make(chan compiledSubscriber)
+	errs := make(chan error, len(subscribers))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for subscriber := range jobs {
+				if !subscriber.filter(event) || !matchesPayloadTypes(subscriber.PayloadTypes, event.PayloadType) {
+					continue
+				}
+
+				if err := s.deliver(topic, subscriber.URL, event, policy); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for _, subscriber := range subscribers {
+		jobs <- subscriber
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(errs)
+
+	var collected []error
+	for err := range errs {
+		collected = append(collected, err)
+	}
+
+	if len(collected) > 0 {
+		return &multiError{Errors: collected}
+	}
+
+	return nil
+}
+
+// deliver attempts to deliver event to url, retrying according to policy on
+// transport errors and non-2xx responses. If every attempt fails, the
+// delivery is handed to the configured DeadLetterSink, if any.
+func (s *HttpEventService) deliver(topic string, url string, event *events.NitricEvent, policy RetryPolicy) error {
+	var lastErr error
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		request, err := s.newRequest(topic, url, event)
+		if err != nil {
+			return err
+		}
+
+		response, err := s.client.Do(request)
+		if err == nil && response == nil {
+			err = fmt.Errorf("subscriber %s: client returned a nil response with no error", url)
+		}
+
+		if err == nil && response.StatusCode >= 200 && response.StatusCode < 300 {
+			return nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("subscriber %s returned non-2xx status: %s", url, response.Status)
+		}
+
+		if attempt < policy.MaxAttempts {
+			s.clock.Sleep(policy.delayFor(attempt))
+		}
+	}
+
+	if s.deadLetterSink != nil {
+		// The failure has been durably captured for later inspection or
+		// redelivery, so it's no longer surfaced as a Publish error.
+		return s.deadLetterSink.Capture(DeadLetterEntry{
+			Topic:     topic,
+			URL:       url,
+			Event:     event,
+			Attempts:  policy.MaxAttempts,
+			LastError: lastErr.Error(),
+		})
+	}
+
+	return lastErr
+}
+
+// newRequest builds the outgoing HTTP request for delivering event to url,
+// using the service's configured EventFormat.
+func (s *HttpEventService) newRequest(topic string, url string, event *events.NitricEvent) (*http.Request, error) {
+	switch s.format {
+	case events.EventFormatCEBinary:
+		return s.newBinaryCloudEventRequest(topic, url, event)
+	case events.EventFormatCEStructured:
+		return s.newStructuredCloudEventRequest(topic, url, event)
+	default:
+		return s.newHeaderRequest(topic, url, event)
+	}
+}
+
+func (s *HttpEventService) newHeaderRequest(topic string, url string, event *events.NitricEvent) (*http.Request, error) {
+	payloadBytes, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("x-nitric-request-id", event.ID)
+	request.Header.Set("x-nitric-payload-type", event.PayloadType)
+	request.Header.Set("x-nitric-source-type", "SUBSCRIPTION")
+	request.Header.Set("x-nitric-source", topic)
+
+	return request, nil
+}
+
+func (s *HttpEventService) newBinaryCloudEventRequest(topic string, url string, event *events.NitricEvent) (*http.Request, error) {
+	payloadBytes, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("ce-id", event.ID)
+	request.Header.Set("ce-source", topic)
+	request.Header.Set("ce-type", event.PayloadType)
+	request.Header.Set("ce-specversion", cloudEventsSpecVersion)
+	request.Header.Set("ce-time", time.Now().UTC().Format(time.RFC3339))
+
+	return request, nil
+}
+
+func (s *HttpEventService) newStructuredCloudEventRequest(topic string, url string, event *events.NitricEvent) (*http.Request, error) {
+	envelope := cloudEventEnvelope{
+		ID:          event.ID,
+		Source:      topic,
+		SpecVersion: cloudEventsSpecVersion,
+		Type:        event.PayloadType,
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		Data:        event.Payload,
+	}
+
+	envelopeBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewReader(envelopeBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/cloudevents+json")
+
+	return request, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+var _ = Describe("CloudEvents formats", func() {
+	mockHttpClient := &MockHttpClient{}
+
+	AfterEach(func() {
+		mockHttpClient.reset()
+	})
+
+	testPayload := map[string]interface{}{
+		"Test": "test",
+	}
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     testPayload,
+	}
+	subs := map[string][]string{
+		"test": {"http://test-endpoint/"},
+	}
+
+	When("Publishing with EventFormatCEBinary", func() {
+		pubsubClient, _ := events_service.NewWithClientAndSubs(mockHttpClient, subs, events_service.WithEventFormat(events.EventFormatCEBinary))
+
+		It("should deliver ce-* headers with the raw payload as the body", func() {
+			err := pubsubClient.Publish("test", testEvent)
+			Expect(err).To(BeNil())
+
+			Expect(mockHttpClient.capturedRequests).To(HaveLen(1))
+			capturedRequest := mockHttpClient.capturedRequests[0]
+
+			By("Providing the event id in the ce-id header")
+			Expect(capturedRequest.Header.Get("ce-id")).To(Equal("1234"))
+
+			By("Providing the topic in the ce-source header")
+			Expect(capturedRequest.Header.Get("ce-source")).To(Equal("test"))
+
+			By("Providing the PayloadType in the ce-type header")
+			Expect(capturedRequest.Header.Get("ce-type")).To(Equal("Test-Payload"))
+
+			By("Providing the CloudEvents spec version")
+			Expect(capturedRequest.Header.Get("ce-specversion")).To(Equal("1.0"))
+
+			By("Providing a ce-time header")
+			Expect(capturedRequest.Header.Get("ce-time")).ToNot(BeEmpty())
+
+			By("Providing the payload, unwrapped, as the body")
+			bodyBytes, err := ioutil.ReadAll(capturedRequest.Body)
+			Expect(err).NotTo(HaveOccurred())
+			bodyMap := make(map[string]interface{})
+			Expect(json.Unmarshal(bodyBytes, &bodyMap)).To(Succeed())
+			Expect(bodyMap).To(BeEquivalentTo(testPayload))
+		})
+	})
+
+	When("Publishing with EventFormatCEStructured", func() {
+		pubsubClient, _ := events_service.NewWithClientAndSubs(mockHttpClient, subs, events_service.WithEventFormat(events.EventFormatCEStructured))
+
+		It("should deliver a full CloudEvents envelope as application/cloudevents+json", func() {
+			err := pubsubClient.Publish("test", testEvent)
+			Expect(err).To(BeNil())
+
+			Expect(mockHttpClient.capturedRequests).To(HaveLen(1))
+			capturedRequest := mockHttpClient.capturedRequests[0]
+
+			By("Setting the structured content type")
+			Expect(capturedRequest.Header.Get("Content-Type")).To(Equal("application/cloudevents+json"))
+
+			By("Encoding the envelope fields and payload in the body")
+			bodyBytes, err := ioutil.ReadAll(capturedRequest.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			envelope := make(map[string]interface{})
+			Expect(json.Unmarshal(bodyBytes, &envelope)).To(Succeed())
+
+			Expect(envelope["id"]).To(Equal("1234"))
+			Expect(envelope["source"]).To(Equal("test"))
+			Expect(envelope["type"]).To(Equal("Test-Payload"))
+			Expect(envelope["specversion"]).To(Equal("1.0"))
+			Expect(envelope["data"]).To(BeEquivalentTo(testPayload))
+		})
+	})
+
+	When("No format option is supplied", func() {
+		pubsubClient, _ := events_service.NewWithClientAndSubs(mockHttpClient, subs)
+
+		It("should fall back to the legacy x-nitric-* header format", func() {
+			err := pubsubClient.Publish("test", testEvent)
+			Expect(err).To(BeNil())
+
+			capturedRequest := mockHttpClient.capturedRequests[0]
+			Expect(capturedRequest.Header.Get("x-nitric-request-id")).To(Equal("1234"))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+// RecordingHttpClient records which URLs were delivered to, safely under
+// concurrent access from the fan-out worker pool.
+type RecordingHttpClient struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (c *RecordingHttpClient) Do(request *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.got = append(c.got, request.URL.String())
+	return &http.Response{Status: "200 OK", StatusCode: 200}, nil
+}
+
+func (c *RecordingHttpClient) urls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	got := make([]string, len(c.got))
+	copy(got, c.got)
+	sort.Strings(got)
+	return got
+}
+
+var _ = Describe("Filtered fan-out", func() {
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload: map[string]interface{}{
+			"region": "us",
+		},
+	}
+
+	When("some subscribers have a matching filter and some don't", func() {
+		It("should only deliver to subscribers whose filter matches", func() {
+			client := &RecordingHttpClient{}
+
+			subs := events_service.SubscriptionMap{
+				"test": {
+					{URL: "http://matches/", Filter: "region == us"},
+					{URL: "http://no-match/", Filter: "region == eu"},
+					{URL: "http://unfiltered/"},
+				},
+			}
+
+			pubsubClient, err := events_service.NewWithClientAndSubscriptions(client, subs)
+			Expect(err).To(BeNil())
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://matches/", "http://unfiltered/"}))
+		})
+	})
+
+	When("a subscriber restricts PayloadTypes", func() {
+		It("should only deliver events with a matching PayloadType", func() {
+			client := &RecordingHttpClient{}
+
+			subs := events_service.SubscriptionMap{
+				"test": {
+					{URL: "http://wanted/", PayloadTypes: []string{"Test-Payload"}},
+					{URL: "http://unwanted/", PayloadTypes: []string{"Other-Payload"}},
+				},
+			}
+
+			pubsubClient, err := events_service.NewWithClientAndSubscriptions(client, subs)
+			Expect(err).To(BeNil())
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://wanted/"}))
+		})
+	})
+
+	When("a filter expression is malformed", func() {
+		It("should fail at construction time, not at publish time", func() {
+			client := &RecordingHttpClient{}
+
+			subs := events_service.SubscriptionMap{
+				"test": {
+					{URL: "http://bad/", Filter: "not a valid expression"},
+				},
+			}
+
+			_, err := events_service.NewWithClientAndSubscriptions(client, subs)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	When("the legacy map[string][]string constructor is used", func() {
+		It("should treat every URL as an unfiltered subscriber", func() {
+			client := &RecordingHttpClient{}
+
+			subs := map[string][]string{
+				"test": {"http://legacy/"},
+			}
+
+			pubsubClient, err := events_service.NewWithClientAndSubs(client, subs)
+			Expect(err).To(BeNil())
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://legacy/"}))
+		})
+	})
+})
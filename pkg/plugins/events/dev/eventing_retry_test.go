@@ -0,0 +1,201 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+// FailingHttpClient fails the first failCount calls with a non-2xx response,
+// then succeeds.
+type FailingHttpClient struct {
+	failCount int
+	calls     int
+}
+
+func (c *FailingHttpClient) Do(request *http.Request) (*http.Response, error) {
+	c.calls++
+
+	if c.calls <= c.failCount {
+		return &http.Response{Status: "500 Internal Server Error", StatusCode: 500}, nil
+	}
+
+	return &http.Response{Status: "200 OK", StatusCode: 200}, nil
+}
+
+// NilResponseHttpClient simulates a misbehaving transport that returns a nil
+// *http.Response alongside a nil error.
+type NilResponseHttpClient struct {
+	calls int
+}
+
+func (c *NilResponseHttpClient) Do(request *http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, nil
+}
+
+// FakeClock records Sleep durations instead of actually sleeping.
+type FakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *FakeClock) Now() time.Time { return c.now }
+
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+var _ = Describe("Retry and dead-lettering", func() {
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     map[string]interface{}{"Test": "test"},
+	}
+	subs := map[string][]string{
+		"test": {"http://test-endpoint/"},
+	}
+
+	When("a subscriber fails fewer times than MaxAttempts", func() {
+		It("should retry until it succeeds, honouring the backoff policy", func() {
+			client := &FailingHttpClient{failCount: 2}
+			clock := &FakeClock{}
+
+			policy := events_service.RetryPolicy{
+				MaxAttempts:  3,
+				InitialDelay: 10 * time.Millisecond,
+				Multiplier:   2,
+			}
+
+			pubsubClient, _ := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithDefaultRetryPolicy(policy),
+				events_service.WithClock(clock),
+			)
+
+			err := pubsubClient.Publish("test", testEvent)
+
+			Expect(err).To(BeNil())
+			Expect(client.calls).To(Equal(3))
+			Expect(clock.sleeps).To(Equal([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}))
+		})
+	})
+
+	When("a subscriber fails every attempt and a dead-letter sink is configured", func() {
+		It("should stop after MaxAttempts and capture the failure in the sink", func() {
+			client := &FailingHttpClient{failCount: 100}
+			clock := &FakeClock{}
+			sink := events_service.NewMemoryDeadLetterSink()
+
+			policy := events_service.RetryPolicy{
+				MaxAttempts:  3,
+				InitialDelay: 5 * time.Millisecond,
+				Multiplier:   1,
+			}
+
+			pubsubClient, _ := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithDefaultRetryPolicy(policy),
+				events_service.WithClock(clock),
+				events_service.WithDeadLetterSink(sink),
+			)
+
+			err := pubsubClient.Publish("test", testEvent)
+
+			By("Not surfacing the failure once it has been dead-lettered")
+			Expect(err).To(BeNil())
+
+			By("Attempting delivery exactly MaxAttempts times")
+			Expect(client.calls).To(Equal(3))
+
+			By("Recording exactly one dead-letter entry")
+			entries := sink.Entries()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Topic).To(Equal("test"))
+			Expect(entries[0].URL).To(Equal("http://test-endpoint/"))
+			Expect(entries[0].Attempts).To(Equal(3))
+			Expect(entries[0].Event.ID).To(Equal("1234"))
+		})
+	})
+
+	When("a subscriber fails every attempt and no dead-letter sink is configured", func() {
+		It("should return the last error", func() {
+			client := &FailingHttpClient{failCount: 100}
+			clock := &FakeClock{}
+
+			policy := events_service.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond, Multiplier: 1}
+
+			pubsubClient, _ := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithDefaultRetryPolicy(policy),
+				events_service.WithClock(clock),
+			)
+
+			err := pubsubClient.Publish("test", testEvent)
+
+			Expect(err).ToNot(BeNil())
+			Expect(client.calls).To(Equal(2))
+		})
+	})
+
+	When("the http client returns a nil response with a nil error", func() {
+		It("should treat it as a transport failure instead of panicking", func() {
+			client := &NilResponseHttpClient{}
+			clock := &FakeClock{}
+
+			policy := events_service.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond, Multiplier: 1}
+
+			pubsubClient, _ := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithDefaultRetryPolicy(policy),
+				events_service.WithClock(clock),
+			)
+
+			var err error
+			Expect(func() { err = pubsubClient.Publish("test", testEvent) }).ToNot(Panic())
+			Expect(err).ToNot(BeNil())
+			Expect(client.calls).To(Equal(2))
+		})
+	})
+
+	When("a policy has fewer than one MaxAttempts", func() {
+		It("should still attempt delivery once instead of panicking on an empty retry loop", func() {
+			client := &FailingHttpClient{failCount: 100}
+			clock := &FakeClock{}
+			sink := events_service.NewMemoryDeadLetterSink()
+
+			policy := events_service.RetryPolicy{MaxAttempts: 0, InitialDelay: time.Millisecond, Multiplier: 1}
+
+			pubsubClient, _ := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithDefaultRetryPolicy(policy),
+				events_service.WithClock(clock),
+				events_service.WithDeadLetterSink(sink),
+			)
+
+			var err error
+			Expect(func() { err = pubsubClient.Publish("test", testEvent) }).ToNot(Panic())
+			Expect(err).To(BeNil())
+			Expect(client.calls).To(Equal(1))
+
+			entries := sink.Entries()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Attempts).To(Equal(1))
+		})
+	})
+})
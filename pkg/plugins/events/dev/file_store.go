@@ -0,0 +1,160 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileSubscriptionStore is a SubscriptionStore backed by a single JSON file
+// on disk. The whole file is rewritten and fsync'd after every mutation, so
+// topic/subscriber state survives a membrane restart.
+type FileSubscriptionStore struct {
+	mu   sync.Mutex
+	path string
+	subs map[string][]Subscriber
+}
+
+// NewFileSubscriptionStore opens (or creates) the JSON store at path and
+// loads any existing topic/subscriber state from it.
+func NewFileSubscriptionStore(path string) (*FileSubscriptionStore, error) {
+	store := &FileSubscriptionStore{path: path, subs: map[string][]Subscriber{}}
+
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+
+	if len(fileBytes) == 0 {
+		return store, nil
+	}
+
+	if err := json.Unmarshal(fileBytes, &store.subs); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// persist rewrites the store's full state to disk and fsyncs it. Callers
+// must hold s.mu.
+func (s *FileSubscriptionStore) persist() error {
+	fileBytes, err := json.MarshalIndent(s.subs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(fileBytes); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func (s *FileSubscriptionStore) ListTopics() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topics := make([]string, 0, len(s.subs))
+	for topic := range s.subs {
+		topics = append(topics, topic)
+	}
+
+	return topics, nil
+}
+
+func (s *FileSubscriptionStore) GetSubscribers(topic string) ([]Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscribers, ok := s.subs[topic]
+	if !ok {
+		return nil, ErrTopicNotFound
+	}
+
+	result := make([]Subscriber, len(subscribers))
+	copy(result, subscribers)
+
+	return result, nil
+}
+
+func (s *FileSubscriptionStore) AddSubscriber(topic string, subscriber Subscriber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; !ok {
+		return ErrTopicNotFound
+	}
+
+	s.subs[topic] = append(s.subs[topic], subscriber)
+
+	return s.persist()
+}
+
+func (s *FileSubscriptionStore) RemoveSubscriber(topic string, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscribers, ok := s.subs[topic]
+	if !ok {
+		return ErrTopicNotFound
+	}
+
+	for i, subscriber := range subscribers {
+		if subscriber.URL == url {
+			s.subs[topic] = append(subscribers[:i], subscribers[i+1:]...)
+			return s.persist()
+		}
+	}
+
+	return nil
+}
+
+func (s *FileSubscriptionStore) CreateTopic(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; ok {
+		return ErrTopicExists
+	}
+
+	s.subs[topic] = []Subscriber{}
+
+	return s.persist()
+}
+
+func (s *FileSubscriptionStore) DeleteTopic(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; !ok {
+		return ErrTopicNotFound
+	}
+
+	delete(s.subs, topic)
+
+	return s.persist()
+}
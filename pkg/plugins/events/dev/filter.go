@@ -0,0 +1,123 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+)
+
+// Subscriber describes a single fan-out target registered against a topic.
+// A subscriber only receives a published event if Filter (when set)
+// evaluates true against the event payload, and PayloadTypes (when set)
+// contains the event's PayloadType.
+type Subscriber struct {
+	URL          string
+	Filter       string
+	PayloadTypes []string
+}
+
+// SubscriptionMap maps topic name to the subscribers registered against it.
+type SubscriptionMap map[string][]Subscriber
+
+// filterFunc reports whether event matches a compiled Filter expression.
+type filterFunc func(event *events.NitricEvent) bool
+
+// compiledSubscriber pairs a Subscriber with its pre-parsed filter, so that
+// an invalid expression is rejected at construction time rather than on
+// every Publish.
+type compiledSubscriber struct {
+	Subscriber
+	filter filterFunc
+}
+
+// parseFilter compiles a simple JSONPath-like equality expression of the
+// form "<dotted.path> == <value>" or "<dotted.path> != <value>", where path
+// is resolved against the event payload. An empty expression always
+// matches.
+func parseFilter(expr string) (filterFunc, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return func(*events.NitricEvent) bool { return true }, nil
+	}
+
+	op := "=="
+	parts := strings.SplitN(expr, "==", 2)
+	if len(parts) != 2 {
+		op = "!="
+		parts = strings.SplitN(expr, "!=", 2)
+	}
+
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid filter expression %q: expected '<payload.path> == <value>' or '<payload.path> != <value>'", expr)
+	}
+
+	path := strings.TrimSpace(parts[0])
+	if path == "" {
+		return nil, fmt.Errorf("invalid filter expression %q: missing payload path", expr)
+	}
+
+	value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+	segments := strings.Split(path, ".")
+
+	return func(event *events.NitricEvent) bool {
+		resolved, ok := resolvePayloadPath(event.Payload, segments)
+		matches := ok && fmt.Sprintf("%v", resolved) == value
+
+		if op == "!=" {
+			return !matches
+		}
+
+		return matches
+	}, nil
+}
+
+// resolvePayloadPath walks segments through a nested payload map, returning
+// the value found at the final segment, or false if any segment is missing.
+func resolvePayloadPath(payload map[string]interface{}, segments []string) (interface{}, bool) {
+	var current interface{} = payload
+
+	for _, segment := range segments {
+		asMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = asMap[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// matchesPayloadTypes reports whether payloadType is accepted by types. An
+// empty types list accepts every payload type.
+func matchesPayloadTypes(types []string, payloadType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, t := range types {
+		if t == payloadType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import "context"
+
+// EventsManagementServer adapts HttpEventService's topic and subscriber
+// management methods to the unary request/response shape produced by
+// protoc-gen-go-grpc. No proto service or gRPC server in this repository
+// registers it; it is called in-process.
+type EventsManagementServer struct {
+	events *HttpEventService
+}
+
+// NewEventsManagementServer wraps events for gRPC exposure.
+func NewEventsManagementServer(events *HttpEventService) *EventsManagementServer {
+	return &EventsManagementServer{events: events}
+}
+
+type ListTopicsRequest struct{}
+
+type ListTopicsResponse struct {
+	Topics []string
+}
+
+func (m *EventsManagementServer) ListTopics(ctx context.Context, req *ListTopicsRequest) (*ListTopicsResponse, error) {
+	topics, err := m.events.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListTopicsResponse{Topics: topics}, nil
+}
+
+type CreateTopicRequest struct {
+	Topic string
+}
+
+type CreateTopicResponse struct{}
+
+func (m *EventsManagementServer) CreateTopic(ctx context.Context, req *CreateTopicRequest) (*CreateTopicResponse, error) {
+	if err := m.events.CreateTopic(req.Topic); err != nil {
+		return nil, err
+	}
+
+	return &CreateTopicResponse{}, nil
+}
+
+type DeleteTopicRequest struct {
+	Topic string
+}
+
+type DeleteTopicResponse struct{}
+
+func (m *EventsManagementServer) DeleteTopic(ctx context.Context, req *DeleteTopicRequest) (*DeleteTopicResponse, error) {
+	if err := m.events.DeleteTopic(req.Topic); err != nil {
+		return nil, err
+	}
+
+	return &DeleteTopicResponse{}, nil
+}
+
+type AddSubscriberRequest struct {
+	Topic      string
+	Subscriber Subscriber
+}
+
+type AddSubscriberResponse struct{}
+
+func (m *EventsManagementServer) AddSubscriber(ctx context.Context, req *AddSubscriberRequest) (*AddSubscriberResponse, error) {
+	if err := m.events.AddSubscriber(req.Topic, req.Subscriber); err != nil {
+		return nil, err
+	}
+
+	return &AddSubscriberResponse{}, nil
+}
+
+type RemoveSubscriberRequest struct {
+	Topic string
+	URL   string
+}
+
+type RemoveSubscriberResponse struct{}
+
+func (m *EventsManagementServer) RemoveSubscriber(ctx context.Context, req *RemoveSubscriberRequest) (*RemoveSubscriberResponse, error) {
+	if err := m.events.RemoveSubscriber(req.Topic, req.URL); err != nil {
+		return nil, err
+	}
+
+	return &RemoveSubscriberResponse{}, nil
+}
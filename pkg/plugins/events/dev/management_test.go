@@ -0,0 +1,88 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+var _ = Describe("EventsManagementServer", func() {
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     map[string]interface{}{"Test": "test"},
+	}
+
+	When("topics and subscribers are managed through the gRPC-shaped server", func() {
+		It("should register them against the underlying plugin", func() {
+			client := &RecordingHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(client, map[string][]string{})
+			Expect(err).To(BeNil())
+
+			manager := events_service.NewEventsManagementServer(pubsubClient.(*events_service.HttpEventService))
+			ctx := context.Background()
+
+			_, err = manager.CreateTopic(ctx, &events_service.CreateTopicRequest{Topic: "dynamic"})
+			Expect(err).To(BeNil())
+
+			_, err = manager.AddSubscriber(ctx, &events_service.AddSubscriberRequest{
+				Topic:      "dynamic",
+				Subscriber: events_service.Subscriber{URL: "http://dynamic-endpoint/"},
+			})
+			Expect(err).To(BeNil())
+
+			listResp, err := manager.ListTopics(ctx, &events_service.ListTopicsRequest{})
+			Expect(err).To(BeNil())
+			Expect(listResp.Topics).To(ContainElement("dynamic"))
+
+			Expect(pubsubClient.Publish("dynamic", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://dynamic-endpoint/"}))
+
+			_, err = manager.RemoveSubscriber(ctx, &events_service.RemoveSubscriberRequest{
+				Topic: "dynamic",
+				URL:   "http://dynamic-endpoint/",
+			})
+			Expect(err).To(BeNil())
+
+			_, err = manager.DeleteTopic(ctx, &events_service.DeleteTopicRequest{Topic: "dynamic"})
+			Expect(err).To(BeNil())
+
+			_, err = manager.ListTopics(ctx, &events_service.ListTopicsRequest{})
+			Expect(err).To(BeNil())
+		})
+	})
+
+	When("a management call fails", func() {
+		It("should surface the underlying plugin error", func() {
+			client := &RecordingHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(client, map[string][]string{})
+			Expect(err).To(BeNil())
+
+			manager := events_service.NewEventsManagementServer(pubsubClient.(*events_service.HttpEventService))
+
+			_, err = manager.AddSubscriber(context.Background(), &events_service.AddSubscriberRequest{
+				Topic:      "unknown",
+				Subscriber: events_service.Subscriber{URL: "http://dynamic-endpoint/"},
+			})
+			Expect(err).ToNot(BeNil())
+		})
+	})
+})
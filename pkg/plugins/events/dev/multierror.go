@@ -0,0 +1,32 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import "strings"
+
+// multiError aggregates the per-subscriber delivery failures from a single
+// fan-out Publish call.
+type multiError struct {
+	Errors []error
+}
+
+func (m *multiError) Error() string {
+	messages := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
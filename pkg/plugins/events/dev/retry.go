@@ -0,0 +1,83 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how many times, and with what backoff, the dev events
+// plugin will attempt to deliver an event to a single subscriber before
+// giving up and, if configured, writing it to the dead-letter sink.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of delivery attempts, including the
+	// first. A value of 1 disables retries.
+	MaxAttempts int
+	// InitialDelay is the backoff applied before the second attempt.
+	InitialDelay time.Duration
+	// Multiplier scales InitialDelay on each subsequent attempt.
+	Multiplier float64
+	// MaxDelay caps the computed backoff, regardless of Multiplier. Zero
+	// means uncapped.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0-1) of the computed delay that is randomized,
+	// to avoid thundering-herd retries across subscribers.
+	Jitter float64
+}
+
+// DefaultRetryPolicy delivers an event once, with no retries, matching the
+// plugin's original behaviour.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  1,
+	InitialDelay: 0,
+	Multiplier:   1,
+	MaxDelay:     0,
+	Jitter:       0,
+}
+
+// delayFor returns the backoff to wait before retry attempt number
+// (attempt + 1), where attempt is the 1-indexed attempt that just failed.
+func (p RetryPolicy) delayFor(attempt int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		jitterRange := delay * p.Jitter
+		delay = delay - jitterRange/2 + rand.Float64()*jitterRange
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
+
+// Clock is injected into HttpEventService so that retry backoff can be
+// exercised deterministically in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
@@ -0,0 +1,138 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTopicExists is returned by SubscriptionStore.CreateTopic when the topic
+// is already registered.
+var ErrTopicExists = errors.New("topic already exists")
+
+// ErrTopicNotFound is returned by SubscriptionStore methods that operate on
+// a topic which hasn't been created.
+var ErrTopicNotFound = errors.New("topic not found")
+
+// SubscriptionStore persists the set of topics and their subscribers for
+// the dev events plugin, independently of how events are actually delivered.
+// Implementations must be safe for concurrent use.
+type SubscriptionStore interface {
+	ListTopics() ([]string, error)
+	GetSubscribers(topic string) ([]Subscriber, error)
+	AddSubscriber(topic string, subscriber Subscriber) error
+	RemoveSubscriber(topic string, url string) error
+	CreateTopic(topic string) error
+	DeleteTopic(topic string) error
+}
+
+// MemorySubscriptionStore is the default SubscriptionStore, holding topic
+// and subscriber state purely in process memory. This matches the plugin's
+// original behaviour.
+type MemorySubscriptionStore struct {
+	mu   sync.RWMutex
+	subs map[string][]Subscriber
+}
+
+// NewMemorySubscriptionStore creates a new, empty MemorySubscriptionStore.
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{subs: map[string][]Subscriber{}}
+}
+
+func (s *MemorySubscriptionStore) ListTopics() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics := make([]string, 0, len(s.subs))
+	for topic := range s.subs {
+		topics = append(topics, topic)
+	}
+
+	return topics, nil
+}
+
+func (s *MemorySubscriptionStore) GetSubscribers(topic string) ([]Subscriber, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscribers, ok := s.subs[topic]
+	if !ok {
+		return nil, ErrTopicNotFound
+	}
+
+	result := make([]Subscriber, len(subscribers))
+	copy(result, subscribers)
+
+	return result, nil
+}
+
+func (s *MemorySubscriptionStore) AddSubscriber(topic string, subscriber Subscriber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; !ok {
+		return ErrTopicNotFound
+	}
+
+	s.subs[topic] = append(s.subs[topic], subscriber)
+
+	return nil
+}
+
+func (s *MemorySubscriptionStore) RemoveSubscriber(topic string, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscribers, ok := s.subs[topic]
+	if !ok {
+		return ErrTopicNotFound
+	}
+
+	for i, subscriber := range subscribers {
+		if subscriber.URL == url {
+			s.subs[topic] = append(subscribers[:i], subscribers[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *MemorySubscriptionStore) CreateTopic(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; ok {
+		return ErrTopicExists
+	}
+
+	s.subs[topic] = []Subscriber{}
+
+	return nil
+}
+
+func (s *MemorySubscriptionStore) DeleteTopic(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[topic]; !ok {
+		return ErrTopicNotFound
+	}
+
+	delete(s.subs, topic)
+
+	return nil
+}
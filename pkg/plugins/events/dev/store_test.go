@@ -0,0 +1,143 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+var _ = Describe("Dynamic subscription management", func() {
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     map[string]interface{}{"Test": "test"},
+	}
+
+	When("CreateTopic and AddSubscriber are called at runtime", func() {
+		It("should make the topic publishable without a restart", func() {
+			client := &RecordingHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(client, map[string][]string{})
+			Expect(err).To(BeNil())
+
+			manager := pubsubClient.(*events_service.HttpEventService)
+
+			Expect(manager.CreateTopic("dynamic")).To(Succeed())
+			Expect(manager.AddSubscriber("dynamic", events_service.Subscriber{URL: "http://dynamic-endpoint/"})).To(Succeed())
+
+			topics, err := pubsubClient.ListTopics()
+			Expect(err).To(BeNil())
+			Expect(topics).To(ContainElement("dynamic"))
+
+			Expect(pubsubClient.Publish("dynamic", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://dynamic-endpoint/"}))
+		})
+	})
+
+	When("RemoveSubscriber and DeleteTopic are called", func() {
+		It("should stop delivering to the removed subscriber and the deleted topic", func() {
+			client := &RecordingHttpClient{}
+			subs := events_service.SubscriptionMap{
+				"test": {{URL: "http://test-endpoint/"}},
+			}
+			pubsubClient, err := events_service.NewWithClientAndSubscriptions(client, subs)
+			Expect(err).To(BeNil())
+
+			manager := pubsubClient.(*events_service.HttpEventService)
+			Expect(manager.RemoveSubscriber("test", "http://test-endpoint/")).To(Succeed())
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+			Expect(client.urls()).To(BeEmpty())
+
+			Expect(manager.DeleteTopic("test")).To(Succeed())
+			_, err = pubsubClient.ListTopics()
+			Expect(err).To(BeNil())
+
+			err = pubsubClient.Publish("test", testEvent)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	When("a FileSubscriptionStore is used", func() {
+		It("should persist topics and subscribers across service restarts", func() {
+			tempDir, err := ioutil.TempDir("", "events-store")
+			Expect(err).To(BeNil())
+			storePath := filepath.Join(tempDir, "subs.json")
+
+			firstStore, err := events_service.NewFileSubscriptionStore(storePath)
+			Expect(err).To(BeNil())
+
+			firstClient := &RecordingHttpClient{}
+			firstService, err := events_service.NewWithClientAndSubs(firstClient, map[string][]string{},
+				events_service.WithSubscriptionStore(firstStore),
+			)
+			Expect(err).To(BeNil())
+
+			manager := firstService.(*events_service.HttpEventService)
+			Expect(manager.CreateTopic("orders")).To(Succeed())
+			Expect(manager.AddSubscriber("orders", events_service.Subscriber{URL: "http://orders-endpoint/"})).To(Succeed())
+
+			secondStore, err := events_service.NewFileSubscriptionStore(storePath)
+			Expect(err).To(BeNil())
+
+			secondClient := &RecordingHttpClient{}
+			secondService, err := events_service.NewWithClientAndSubs(secondClient, map[string][]string{},
+				events_service.WithSubscriptionStore(secondStore),
+			)
+			Expect(err).To(BeNil())
+
+			topics, err := secondService.ListTopics()
+			Expect(err).To(BeNil())
+			Expect(topics).To(ContainElement("orders"))
+
+			Expect(secondService.Publish("orders", testEvent)).To(BeNil())
+			Expect(secondClient.urls()).To(Equal([]string{"http://orders-endpoint/"}))
+		})
+	})
+
+	When("a bootstrap subscription map is combined with a pre-populated store", func() {
+		It("should migrate the bootstrap subscribers in without duplicating existing ones", func() {
+			tempDir, err := ioutil.TempDir("", "events-store")
+			Expect(err).To(BeNil())
+			storePath := filepath.Join(tempDir, "subs.json")
+
+			seedStore, err := events_service.NewFileSubscriptionStore(storePath)
+			Expect(err).To(BeNil())
+			Expect(seedStore.CreateTopic("test")).To(Succeed())
+			Expect(seedStore.AddSubscriber("test", events_service.Subscriber{URL: "http://test-endpoint/"})).To(Succeed())
+
+			reopened, err := events_service.NewFileSubscriptionStore(storePath)
+			Expect(err).To(BeNil())
+
+			client := &RecordingHttpClient{}
+			subs := map[string][]string{
+				"test": {"http://test-endpoint/", "http://bootstrap-endpoint/"},
+			}
+
+			pubsubClient, err := events_service.NewWithClientAndSubs(client, subs,
+				events_service.WithSubscriptionStore(reopened),
+			)
+			Expect(err).To(BeNil())
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+			Expect(client.urls()).To(Equal([]string{"http://bootstrap-endpoint/", "http://test-endpoint/"}))
+		})
+	})
+})
@@ -0,0 +1,290 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+)
+
+// channelSubscriberBuffer is the per-subscriber channel capacity. When a
+// subscriber falls behind by more than this many events, further sends are
+// dropped rather than blocking Publish; each dropped event is sent again
+// once its redelivery timeout elapses.
+const channelSubscriberBuffer = 16
+
+// defaultRedeliveryTimeout is how long a channel subscriber has to Ack a
+// Delivery before it is automatically redelivered.
+const defaultRedeliveryTimeout = 30 * time.Second
+
+// channelSubscription is a single Subscribe caller's delivery channel.
+// closed is read and written only while holding the owning
+// HttpEventService's channelSubsMu, which also guards every send to ch --
+// that single lock is what prevents notifyChannelSubscribers racing with
+// cancel's close(ch).
+type channelSubscription struct {
+	ch     chan *events.Delivery
+	closed bool
+}
+
+// Subscribe registers a channel-based subscriber for topic, returning the
+// channel Deliveries are sent on and a cancel function that unregisters the
+// subscriber and closes the channel. It lets functions able to hold a
+// long-lived connection receive events instead of exposing an HTTP
+// callback, and WatchTopic streams its Deliveries. Events that
+// aren't Acked (or Nacked) within the configured redelivery timeout are
+// sent again.
+func (s *HttpEventService) Subscribe(topic string) (<-chan *events.Delivery, func(), error) {
+	s.subsMu.RLock()
+	_, ok := s.subs[topic]
+	s.subsMu.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("topic %s does not exist", topic)
+	}
+
+	sub := &channelSubscription{ch: make(chan *events.Delivery, channelSubscriberBuffer)}
+
+	s.channelSubsMu.Lock()
+	s.channelSubs[topic] = append(s.channelSubs[topic], sub)
+	s.channelSubsMu.Unlock()
+
+	cancel := func() {
+		s.channelSubsMu.Lock()
+		defer s.channelSubsMu.Unlock()
+
+		subscribers := s.channelSubs[topic]
+		for i, existing := range subscribers {
+			if existing == sub {
+				s.channelSubs[topic] = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+
+		sub.closed = true
+		close(sub.ch)
+	}
+
+	return sub.ch, cancel, nil
+}
+
+// notifyChannelSubscribers delivers event to every channel subscriber
+// currently registered for topic.
+func (s *HttpEventService) notifyChannelSubscribers(topic string, event *events.NitricEvent) {
+	s.channelSubsMu.Lock()
+	defer s.channelSubsMu.Unlock()
+
+	for _, sub := range s.channelSubs[topic] {
+		s.sendToSubscriber(topic, sub, event)
+	}
+}
+
+// sendToSubscriber sends event to sub as a new Delivery and arms its
+// redelivery timer. Callers must hold s.channelSubsMu, and must have
+// already confirmed sub.closed is false.
+func (s *HttpEventService) sendToSubscriber(topic string, sub *channelSubscription, event *events.NitricEvent) {
+	var once sync.Once
+	var timer *time.Timer
+
+	delivery := &events.Delivery{Event: event}
+	delivery.AckFunc = func() {
+		once.Do(func() {
+			timer.Stop()
+		})
+	}
+	delivery.NackFunc = func() {
+		once.Do(func() {
+			timer.Stop()
+			s.redeliver(topic, sub, event)
+		})
+	}
+
+	timeout := s.redeliveryTimeout
+	if timeout <= 0 {
+		timeout = defaultRedeliveryTimeout
+	}
+	timer = time.AfterFunc(timeout, delivery.NackFunc)
+
+	select {
+	case sub.ch <- delivery:
+	default:
+		// Subscriber buffer is full; drop this attempt. The redelivery
+		// timer is still armed, so the event is retried shortly.
+	}
+}
+
+// redeliver re-sends event to sub, provided the subscription hasn't since
+// been cancelled.
+func (s *HttpEventService) redeliver(topic string, sub *channelSubscription, event *events.NitricEvent) {
+	s.channelSubsMu.Lock()
+	defer s.channelSubsMu.Unlock()
+
+	if sub.closed {
+		return
+	}
+
+	s.sendToSubscriber(topic, sub, event)
+}
+
+// ChannelSubscriberCount returns the number of active channel subscribers
+// (from Subscribe or WatchTopic) currently registered for topic. Since
+// registering a subscriber happens asynchronously relative to the caller --
+// e.g. on WatchTopic's own goroutine -- callers that need to know a
+// subscription is active before publishing (tests included) should poll
+// this rather than racing the subscribe call.
+func (s *HttpEventService) ChannelSubscriberCount(topic string) int {
+	s.channelSubsMu.Lock()
+	defer s.channelSubsMu.Unlock()
+
+	return len(s.channelSubs[topic])
+}
+
+// WatchTopicStream is the server side of the membrane's streaming WatchTopic
+// RPC, shaped to match the Send/Recv pair protoc-gen-go-grpc generates for a
+// bidirectional streaming method. No proto method or gRPC server in this
+// repository registers WatchTopic; tests substitute an in-memory fake.
+type WatchTopicStream interface {
+	Send(*WatchTopicEvent) error
+	Recv() (*WatchTopicAck, error)
+}
+
+// WatchTopicEvent is a single delivery pushed to a WatchTopic caller. Id
+// correlates the event with the WatchTopicAck that acknowledges it.
+type WatchTopicEvent struct {
+	Id    string
+	Event *events.NitricEvent
+}
+
+// WatchTopicAck acknowledges, or negatively acknowledges, the
+// WatchTopicEvent with the matching Id. An event that is neither acked nor
+// nacked is redelivered automatically once the subscription's redelivery
+// timeout elapses, the same as an unacked Delivery from Subscribe.
+type WatchTopicAck struct {
+	Id   string
+	Nack bool
+}
+
+// WatchTopic subscribes to topic and streams every published event to
+// stream as a WatchTopicEvent, applying WatchTopicAcks read back from
+// stream to the matching in-flight Delivery. It is the gRPC counterpart to
+// Subscribe, and blocks until stream.Recv returns an error -- typically
+// because the caller disconnected -- stream.Send returns an error, or the
+// subscription's channel is closed.
+func (s *HttpEventService) WatchTopic(topic string, stream WatchTopicStream) error {
+	ch, cancel, err := s.Subscribe(topic)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	pending := newPendingDeliveries()
+
+	acks := make(chan *WatchTopicAck)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			acks <- ack
+		}
+	}()
+
+	for {
+		select {
+		case delivery, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			id := pending.add(delivery)
+
+			if err := stream.Send(&WatchTopicEvent{Id: id, Event: delivery.Event}); err != nil {
+				pending.remove(id)
+				return err
+			}
+		case ack := <-acks:
+			pending.resolve(ack.Id, ack.Nack)
+		case err := <-recvErr:
+			return err
+		}
+	}
+}
+
+// pendingDeliveries tracks Deliveries sent over a WatchTopicStream that are
+// awaiting a WatchTopicAck, keyed by the correlation id generated for each.
+type pendingDeliveries struct {
+	mu         sync.Mutex
+	deliveries map[string]*events.Delivery
+}
+
+func newPendingDeliveries() *pendingDeliveries {
+	return &pendingDeliveries{deliveries: map[string]*events.Delivery{}}
+}
+
+func (p *pendingDeliveries) add(delivery *events.Delivery) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := newCorrelationID()
+	p.deliveries[id] = delivery
+
+	return id
+}
+
+func (p *pendingDeliveries) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.deliveries, id)
+}
+
+func (p *pendingDeliveries) resolve(id string, nack bool) {
+	p.mu.Lock()
+	delivery, ok := p.deliveries[id]
+	if ok {
+		delete(p.deliveries, id)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if nack {
+		delivery.Nack()
+	} else {
+		delivery.Ack()
+	}
+}
+
+// newCorrelationID generates a short random id for correlating a
+// WatchTopicEvent with its eventual WatchTopicAck.
+func newCorrelationID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("watch-%p", buf)
+	}
+
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_service_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	events_service "github.com/nitrictech/nitric/pkg/plugins/events/dev"
+)
+
+// fakeWatchTopicStream is an in-memory events_service.WatchTopicStream,
+// standing in for a gRPC server stream.
+type fakeWatchTopicStream struct {
+	mu     sync.Mutex
+	sent   []*events_service.WatchTopicEvent
+	acks   chan *events_service.WatchTopicAck
+	closed chan struct{}
+}
+
+func newFakeWatchTopicStream() *fakeWatchTopicStream {
+	return &fakeWatchTopicStream{
+		acks:   make(chan *events_service.WatchTopicAck, 8),
+		closed: make(chan struct{}),
+	}
+}
+
+func (f *fakeWatchTopicStream) Send(event *events_service.WatchTopicEvent) error {
+	f.mu.Lock()
+	f.sent = append(f.sent, event)
+	f.mu.Unlock()
+
+	return nil
+}
+
+func (f *fakeWatchTopicStream) Recv() (*events_service.WatchTopicAck, error) {
+	select {
+	case ack := <-f.acks:
+		return ack, nil
+	case <-f.closed:
+		return nil, fmt.Errorf("stream closed")
+	}
+}
+
+func (f *fakeWatchTopicStream) events() []*events_service.WatchTopicEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]*events_service.WatchTopicEvent, len(f.sent))
+	copy(result, f.sent)
+
+	return result
+}
+
+var _ = Describe("Streaming subscriptions", func() {
+	subs := map[string][]string{
+		"test": {},
+	}
+
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     map[string]interface{}{"Test": "test"},
+	}
+
+	When("a channel subscriber is active for a topic", func() {
+		It("should receive published events on its channel", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs)
+			Expect(err).To(BeNil())
+
+			subscribable, ok := pubsubClient.(events.Subscribable)
+			Expect(ok).To(BeTrue())
+
+			ch, cancel, err := subscribable.Subscribe("test")
+			Expect(err).To(BeNil())
+			defer cancel()
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+
+			var delivery *events.Delivery
+			Eventually(ch, time.Second).Should(Receive(&delivery))
+			Expect(delivery.Event).To(Equal(testEvent))
+		})
+	})
+
+	When("Subscribe is called for an unknown topic", func() {
+		It("should return an error", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs)
+			Expect(err).To(BeNil())
+
+			subscribable := pubsubClient.(events.Subscribable)
+			_, _, err = subscribable.Subscribe("unknown")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	When("a subscription is cancelled", func() {
+		It("should close the channel and stop receiving further events", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs)
+			Expect(err).To(BeNil())
+
+			subscribable := pubsubClient.(events.Subscribable)
+			ch, cancel, err := subscribable.Subscribe("test")
+			Expect(err).To(BeNil())
+
+			cancel()
+
+			_, open := <-ch
+			Expect(open).To(BeFalse())
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+		})
+	})
+
+	When("a delivery is acked", func() {
+		It("should not be redelivered once the redelivery timeout elapses", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs,
+				events_service.WithRedeliveryTimeout(10*time.Millisecond),
+			)
+			Expect(err).To(BeNil())
+
+			subscribable := pubsubClient.(events.Subscribable)
+			ch, cancel, err := subscribable.Subscribe("test")
+			Expect(err).To(BeNil())
+			defer cancel()
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+
+			var delivery *events.Delivery
+			Eventually(ch, time.Second).Should(Receive(&delivery))
+			delivery.Ack()
+
+			Consistently(ch, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	When("a delivery is neither acked nor nacked", func() {
+		It("should be redelivered once the redelivery timeout elapses", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs,
+				events_service.WithRedeliveryTimeout(10*time.Millisecond),
+			)
+			Expect(err).To(BeNil())
+
+			subscribable := pubsubClient.(events.Subscribable)
+			ch, cancel, err := subscribable.Subscribe("test")
+			Expect(err).To(BeNil())
+			defer cancel()
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+
+			var first *events.Delivery
+			Eventually(ch, time.Second).Should(Receive(&first))
+
+			var second *events.Delivery
+			Eventually(ch, time.Second).Should(Receive(&second))
+			Expect(second.Event).To(Equal(testEvent))
+		})
+	})
+})
+
+var _ = Describe("WatchTopic", func() {
+	subs := map[string][]string{
+		"test": {},
+	}
+
+	testEvent := &events.NitricEvent{
+		ID:          "1234",
+		PayloadType: "Test-Payload",
+		Payload:     map[string]interface{}{"Test": "test"},
+	}
+
+	When("an event is published while a caller is watching a topic", func() {
+		It("should stream the event and apply the returned ack", func() {
+			mockHttpClient := &MockHttpClient{}
+			pubsubClient, err := events_service.NewWithClientAndSubs(mockHttpClient, subs,
+				events_service.WithRedeliveryTimeout(50*time.Millisecond),
+			)
+			Expect(err).To(BeNil())
+
+			manager := pubsubClient.(*events_service.HttpEventService)
+			stream := newFakeWatchTopicStream()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- manager.WatchTopic("test", stream)
+			}()
+
+			Eventually(func() int { return manager.ChannelSubscriberCount("test") }, time.Second).Should(Equal(1))
+
+			Expect(pubsubClient.Publish("test", testEvent)).To(BeNil())
+
+			Eventually(stream.events, time.Second).Should(HaveLen(1))
+			Expect(stream.events()[0].Event).To(Equal(testEvent))
+
+			stream.acks <- &events_service.WatchTopicAck{Id: stream.events()[0].Id}
+
+			Consistently(stream.events, 100*time.Millisecond).Should(HaveLen(1))
+
+			close(stream.closed)
+			Eventually(done, time.Second).Should(Receive())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+// Copyright 2021 Nitric Pty Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import "fmt"
+
+// NitricEvent represents a single event to be published to a topic.
+type NitricEvent struct {
+	ID          string                 `json:"id"`
+	PayloadType string                 `json:"payloadType"`
+	Payload     map[string]interface{} `json:"payload"`
+}
+
+// EventFormat controls the wire format used by an EventService implementation
+// when delivering events to subscribers.
+type EventFormat int
+
+const (
+	// EventFormatHeaders delivers the event payload as the request body with
+	// the event metadata carried in x-nitric-* headers. This is the original
+	// Nitric delivery format.
+	EventFormatHeaders EventFormat = iota
+	// EventFormatCEBinary delivers the event using the CloudEvents 1.0 HTTP
+	// binary content mode: metadata is carried in ce-* headers and the
+	// request body is the raw event payload.
+	EventFormatCEBinary
+	// EventFormatCEStructured delivers the event using the CloudEvents 1.0
+	// HTTP structured content mode: the full CloudEvents envelope, including
+	// the payload, is encoded as the application/cloudevents+json body.
+	EventFormatCEStructured
+)
+
+// EventService is the interface that event plugins must implement to support
+// publishing events to topics and discovering available topics.
+type EventService interface {
+	Publish(topic string, event *NitricEvent) error
+	ListTopics() ([]string, error)
+}
+
+// Delivery wraps a NitricEvent delivered over a streaming subscription. The
+// receiver must call Ack once the event has been processed, or Nack to
+// request immediate redelivery; if neither is called before the
+// subscription's redelivery timeout elapses, the event is redelivered
+// automatically.
+type Delivery struct {
+	Event *NitricEvent
+
+	AckFunc  func()
+	NackFunc func()
+}
+
+// Ack acknowledges successful processing of the delivery, cancelling any
+// pending redelivery.
+func (d *Delivery) Ack() {
+	if d.AckFunc != nil {
+		d.AckFunc()
+	}
+}
+
+// Nack requests immediate redelivery of the event, as if the redelivery
+// timeout had already elapsed.
+func (d *Delivery) Nack() {
+	if d.NackFunc != nil {
+		d.NackFunc()
+	}
+}
+
+// Subscribable is implemented by EventService plugins that, in addition to
+// HTTP fan-out, can deliver events over a long-lived streaming subscription
+// (e.g. the dev plugin's WatchTopic). Not every plugin supports
+// this, so it's a separate, optional interface rather than part of
+// EventService.
+type Subscribable interface {
+	Subscribe(topic string) (<-chan *Delivery, func(), error)
+}
+
+// UnimplementedeventsPlugin is the default EventService implementation used
+// until a provider specific plugin is configured.
+type UnimplementedeventsPlugin struct{}
+
+var _ EventService = &UnimplementedeventsPlugin{}
+
+func (*UnimplementedeventsPlugin) Publish(topic string, event *NitricEvent) error {
+	return fmt.Errorf("UNIMPLEMENTED")
+}
+
+func (*UnimplementedeventsPlugin) ListTopics() ([]string, error) {
+	return nil, fmt.Errorf("UNIMPLEMENTED")
+}